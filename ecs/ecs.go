@@ -1,7 +1,9 @@
 package ecs
 
 import (
+	"fmt"
 	"sort"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/yohamta/donburi"
@@ -16,6 +18,13 @@ type ECS struct {
 	Time *Time
 	// ScriptSystem manages the scripts of the world.
 	ScriptSystem *ScriptSystem
+	// EventSystem queues and dispatches events published through
+	// ecs.Publish to handlers registered through ecs.Subscribe. Unlike
+	// ScriptSystem, it is not registered through AddSystem: ECS.Update
+	// flushes it directly at fixed points in the frame, so it has no
+	// Priority relative to other systems and doesn't appear in ECS.Stats
+	// or DebugDraw.
+	EventSystem *EventSystem
 
 	*innerECS
 }
@@ -26,22 +35,71 @@ type SystemOpts struct {
 	Image *ebiten.Image
 	// Priority is the priority of the system.
 	Priority int
+	// Requires lists the component types the system needs on an entity to
+	// process it. The ECS builds a cached query from this list and skips
+	// the system entirely when no entity matches. With DONBURI_DEBUG=1,
+	// reads made through AccessComponent panic if the component isn't
+	// listed here or in Uses; systems that read components directly
+	// through donburi (e.g. a ComponentType's own Get) are not checked,
+	// since that path doesn't go through the ECS.
+	Requires []*donburi.ComponentType
+	// Uses lists component types the system may access on a matched entity
+	// but does not require for the entity to be processed, e.g. optional
+	// modifiers. See Requires for the access-enforcement rules.
+	Uses []*donburi.ComponentType
+	// Layer routes a Drawer to the named layer registered with AddLayer,
+	// instead of Image. Layer takes precedence over Image if both are set.
+	// Draw panics if Layer names a layer that was never registered with
+	// AddLayer, rather than silently falling back to drawing on screen.
+	Layer string
+	// CacheEntities lets the ECS reuse the Requires query's matched-entity
+	// list across frames instead of re-running it on every Update or Draw,
+	// only recomputing it when a relevant component is created, removed, or
+	// added/removed from an entity. Only set this if every entity and
+	// component mutation this system could be affected by goes through
+	// ecs.CreateEntity, ecs.AddComponent, ecs.RemoveComponent, or
+	// ecs.QueueRemove instead of the raw donburi API — there is no way for
+	// the ECS to see mutations that don't. Left false (the default), the
+	// query re-runs every call, same as a system with no Requires at all.
+	CacheEntities bool
 }
 
 type innerECS struct {
 	updaters []*updater
 	drawers  []*drawer
+
+	removalPoint RemovalPoint
+	removeQueue  []*donburi.Entry
+
+	layers []*layer
+
+	changeGen     uint64
+	touchedGen    map[*donburi.ComponentType]uint64
+	structuralGen uint64
+
+	profilingEnabled bool
 }
 
 type updater struct {
-	Updater  Updater
-	Priority int
+	Updater    Updater
+	Priority   int
+	reqs       requirements
+	matched    []*donburi.Entry
+	cacheValid bool
+	seenGen    uint64
+	profile    profile
 }
 
 type drawer struct {
-	Drawer   Drawer
-	Priority int
-	Image    *ebiten.Image
+	Drawer     Drawer
+	Priority   int
+	Image      *ebiten.Image
+	Layer      string
+	reqs       requirements
+	matched    []*donburi.Entry
+	cacheValid bool
+	seenGen    uint64
+	profile    profile
 }
 
 // NewECS creates a new ECS with the specified world.
@@ -58,6 +116,8 @@ func NewECS(w donburi.World) *ECS {
 	ecs.ScriptSystem = NewScriptSystem()
 	ecs.AddSystem(ecs.ScriptSystem, &SystemOpts{})
 
+	ecs.EventSystem = NewEventSystem()
+
 	return ecs
 }
 
@@ -73,11 +133,15 @@ func (ecs *ECS) AddSystem(system interface{}, opts *SystemOpts) {
 	if opts == nil {
 		opts = &SystemOpts{}
 	}
+	reqs := newRequirements(opts.Requires, opts.Uses, opts.CacheEntities)
+	name := fmt.Sprintf("%T", system)
 	flag := false
 	if system, ok := system.(Updater); ok {
 		ecs.addUpdater(&updater{
 			Updater:  system,
 			Priority: opts.Priority,
+			reqs:     reqs,
+			profile:  profile{name: name},
 		})
 		flag = true
 	}
@@ -86,6 +150,9 @@ func (ecs *ECS) AddSystem(system interface{}, opts *SystemOpts) {
 			Drawer:   system,
 			Priority: opts.Priority,
 			Image:    opts.Image,
+			Layer:    opts.Layer,
+			reqs:     reqs,
+			profile:  profile{name: name},
 		})
 		flag = true
 	}
@@ -104,7 +171,26 @@ func (ecs *ECS) AddScript(q *query.Query, script interface{}, opts *ScriptOpts)
 func (ecs *ECS) Update() {
 	ecs.Time.Update()
 	for _, u := range ecs.updaters {
-		u.Updater.Update(ecs)
+		if u.reqs.query != nil && (!u.reqs.cacheEntities || !u.cacheValid || u.reqs.touchedBy(ecs, u.seenGen)) {
+			u.matched = u.reqs.matchedEntries(ecs.World)
+			u.cacheValid = true
+			u.seenGen = ecs.changeGen
+		}
+		if u.reqs.query != nil && len(u.matched) == 0 {
+			continue
+		}
+		if ecs.profilingEnabled {
+			start := time.Now()
+			u.Updater.Update(ecs)
+			u.profile.record(len(u.matched), time.Since(start))
+		} else {
+			u.Updater.Update(ecs)
+		}
+		ecs.EventSystem.flush(ecs, FlushEndOfUpdater, -1)
+	}
+	ecs.EventSystem.flush(ecs, FlushEndOfFrame, -1)
+	if ecs.removalPoint == RemovalEndOfUpdate {
+		ecs.Flush()
 	}
 }
 
@@ -123,12 +209,41 @@ func (ecs *ECS) addDrawer(entry *drawer) {
 
 // Draw calls Drawer's Draw() methods.
 func (ecs *ECS) Draw(screen *ebiten.Image) {
+	if ecs.removalPoint == RemovalBeforeDraw {
+		ecs.Flush()
+	}
+	for _, l := range ecs.layers {
+		l.ensure(screen)
+	}
 	for _, d := range ecs.drawers {
-		if d.Image != nil {
-			d.Drawer.Draw(ecs, d.Image)
+		if d.reqs.query != nil && (!d.reqs.cacheEntities || !d.cacheValid || d.reqs.touchedBy(ecs, d.seenGen)) {
+			d.matched = d.reqs.matchedEntries(ecs.World)
+			d.cacheValid = true
+			d.seenGen = ecs.changeGen
+		}
+		if d.reqs.query != nil && len(d.matched) == 0 {
 			continue
 		}
-		d.Drawer.Draw(ecs, screen)
+		target := screen
+		if d.Layer != "" {
+			l := ecs.layerByName(d.Layer)
+			if l == nil {
+				panic(fmt.Sprintf("donburi/ecs: %T routed to layer %q, which was never registered with AddLayer", d.Drawer, d.Layer))
+			}
+			target = l.image
+		} else if d.Image != nil {
+			target = d.Image
+		}
+		if ecs.profilingEnabled {
+			start := time.Now()
+			d.Drawer.Draw(ecs, target)
+			d.profile.record(len(d.matched), time.Since(start))
+		} else {
+			d.Drawer.Draw(ecs, target)
+		}
+	}
+	for _, l := range ecs.layers {
+		l.composite(screen)
 	}
 }
 
@@ -0,0 +1,188 @@
+package ecs
+
+import "sort"
+
+// FlushMode controls when an event type's queued events are delivered to
+// subscribers.
+type FlushMode int
+
+const (
+	// FlushEndOfUpdater delivers an event type's queue right after the
+	// Updater that published it returns. This is the default.
+	FlushEndOfUpdater FlushMode = iota
+	// FlushEndOfFrame delivers an event type's queue once, after every
+	// Updater has run for the current Update call.
+	FlushEndOfFrame
+	// FlushImmediate delivers an event synchronously, inside the call to
+	// Publish.
+	FlushImmediate
+)
+
+// EventTypeOpts configures a registered event type.
+type EventTypeOpts struct {
+	// Priority controls delivery order relative to other event types within
+	// the same flush point; higher priority is delivered first.
+	Priority int
+	// Sticky keeps only the most recently published payload. A new Publish
+	// call replaces the pending value instead of queueing alongside it,
+	// which suits state-change signals where only the latest value matters.
+	Sticky bool
+	// FlushMode controls when this event type's queue is delivered.
+	FlushMode FlushMode
+}
+
+// EventType identifies a kind of event carrying a payload of type T.
+// Create one with RegisterEventType.
+type EventType[T any] struct {
+	id int
+}
+
+var eventTypeRegistry []eventTypeState
+
+// eventTypeState holds the type-erased configuration and subscribers for an
+// EventType, keyed by EventType.id.
+type eventTypeState struct {
+	opts      EventTypeOpts
+	handlers  []*eventHandler
+	nextHID   int
+	hasSticky bool
+	sticky    any
+}
+
+type eventHandler struct {
+	id      int
+	fn      func(*ECS, any)
+	removed bool
+}
+
+// RegisterEventType creates a new, distinct event type for payload T. Call
+// it once per event kind, typically from a package-level var block, and
+// reuse the returned EventType with Publish and Subscribe.
+func RegisterEventType[T any](opts ...EventTypeOpts) EventType[T] {
+	o := EventTypeOpts{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	id := len(eventTypeRegistry)
+	eventTypeRegistry = append(eventTypeRegistry, eventTypeState{opts: o})
+	return EventType[T]{id: id}
+}
+
+// Publish queues payload for delivery on evt's configured flush point. With
+// FlushImmediate it is delivered synchronously before Publish returns.
+func Publish[T any](ecs *ECS, evt EventType[T], payload T) {
+	es := ecs.EventSystem
+	state := es.state(evt.id)
+	if state.opts.Sticky {
+		state.hasSticky = true
+		state.sticky = payload
+	} else {
+		es.queue = append(es.queue, eventQueueEntry{typeID: evt.id, payload: payload})
+	}
+	if state.opts.FlushMode == FlushImmediate {
+		es.flush(ecs, FlushImmediate, evt.id)
+	}
+}
+
+// Subscribe registers handler to run whenever evt is delivered. The returned
+// function unsubscribes handler; it is safe to call during dispatch,
+// including from within the handler itself.
+func Subscribe[T any](ecs *ECS, evt EventType[T], handler func(*ECS, T)) func() {
+	es := ecs.EventSystem
+	state := es.state(evt.id)
+	state.nextHID++
+	h := &eventHandler{
+		id: state.nextHID,
+		fn: func(ecs *ECS, payload any) { handler(ecs, payload.(T)) },
+	}
+	state.handlers = append(state.handlers, h)
+	return func() { h.removed = true }
+}
+
+type eventQueueEntry struct {
+	typeID  int
+	payload any
+}
+
+// EventSystem queues events published through Publish and dispatches them
+// to handlers registered through Subscribe at the flush points defined by
+// ECS.Update. NewECS creates it and ECS.Update flushes it directly by
+// hardcoded calls, rather than through AddSystem, so it carries no
+// Priority and is invisible to ECS.Stats/DebugDraw.
+type EventSystem struct {
+	queue  []eventQueueEntry
+	states map[int]*eventTypeState
+}
+
+// NewEventSystem creates a new EventSystem.
+func NewEventSystem() *EventSystem {
+	return &EventSystem{
+		states: map[int]*eventTypeState{},
+	}
+}
+
+func (es *EventSystem) state(typeID int) *eventTypeState {
+	if s, ok := es.states[typeID]; ok {
+		return s
+	}
+	s := eventTypeRegistry[typeID]
+	es.states[typeID] = &s
+	return &s
+}
+
+// flush delivers every queued event whose type matches mode. If onlyTypeID
+// is non-negative, only that event type is considered, which is how
+// FlushImmediate delivers a single just-published event. Flushing drains the
+// queue in a loop so events published by a handler during dispatch (including
+// by the handler that triggered this flush) are delivered before flush
+// returns.
+func (es *EventSystem) flush(ecs *ECS, mode FlushMode, onlyTypeID int) {
+	for {
+		var pending []eventQueueEntry
+		var remaining []eventQueueEntry
+		for _, entry := range es.queue {
+			state := es.state(entry.typeID)
+			match := state.opts.FlushMode == mode && (onlyTypeID < 0 || entry.typeID == onlyTypeID)
+			if match {
+				pending = append(pending, entry)
+			} else {
+				remaining = append(remaining, entry)
+			}
+		}
+		es.queue = remaining
+
+		for id, state := range es.states {
+			if state.hasSticky && state.opts.FlushMode == mode && (onlyTypeID < 0 || id == onlyTypeID) {
+				pending = append(pending, eventQueueEntry{typeID: id, payload: state.sticky})
+				state.hasSticky = false
+			}
+		}
+
+		if len(pending) == 0 {
+			return
+		}
+
+		sort.SliceStable(pending, func(i, j int) bool {
+			return es.state(pending[i].typeID).opts.Priority > es.state(pending[j].typeID).opts.Priority
+		})
+
+		for _, entry := range pending {
+			es.dispatch(ecs, entry)
+		}
+	}
+}
+
+func (es *EventSystem) dispatch(ecs *ECS, entry eventQueueEntry) {
+	state := es.state(entry.typeID)
+	handlers := state.handlers
+	state.handlers = handlers[:0:0]
+	for _, h := range handlers {
+		if h.removed {
+			continue
+		}
+		h.fn(ecs, entry.payload)
+		if !h.removed {
+			state.handlers = append(state.handlers, h)
+		}
+	}
+}
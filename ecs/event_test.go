@@ -0,0 +1,121 @@
+package ecs
+
+import "testing"
+
+type testEvtA struct{ n int }
+
+func newTestECS() *ECS {
+	return NewECS(nil)
+}
+
+func TestEventOrdering(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA]()
+
+	var got []int
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) { got = append(got, p.n) })
+
+	Publish(e, evt, testEvtA{1})
+	Publish(e, evt, testEvtA{2})
+	Publish(e, evt, testEvtA{3})
+
+	e.EventSystem.flush(e, FlushEndOfUpdater, -1)
+
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("events not delivered in publish order: %v", got)
+	}
+}
+
+func TestEventUnsubscribeDuringDispatch(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA]()
+
+	var calls int
+	var unsubscribe func()
+	unsubscribe = Subscribe(e, evt, func(ecs *ECS, p testEvtA) {
+		calls++
+		unsubscribe()
+	})
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) { calls++ })
+
+	Publish(e, evt, testEvtA{1})
+	e.EventSystem.flush(e, FlushEndOfUpdater, -1)
+
+	Publish(e, evt, testEvtA{2})
+	e.EventSystem.flush(e, FlushEndOfUpdater, -1)
+
+	if calls != 3 {
+		t.Fatalf("expected 3 handler calls (2 on first publish, 1 on second), got %d", calls)
+	}
+}
+
+func TestEventReentrantPublish(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA]()
+
+	var got []int
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) {
+		got = append(got, p.n)
+		if p.n == 1 {
+			Publish(ecs, evt, testEvtA{2})
+		}
+	})
+
+	Publish(e, evt, testEvtA{1})
+	e.EventSystem.flush(e, FlushEndOfUpdater, -1)
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("re-entrant publish not delivered within the same flush: %v", got)
+	}
+}
+
+func TestEventSticky(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA](EventTypeOpts{Sticky: true})
+
+	Publish(e, evt, testEvtA{1})
+	Publish(e, evt, testEvtA{2})
+	Publish(e, evt, testEvtA{3})
+
+	var got []int
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) { got = append(got, p.n) })
+
+	e.EventSystem.flush(e, FlushEndOfUpdater, -1)
+
+	if len(got) != 1 || got[0] != 3 {
+		t.Fatalf("sticky event should deliver only the latest value, got %v", got)
+	}
+}
+
+func TestEventFlushImmediate(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA](EventTypeOpts{FlushMode: FlushImmediate})
+
+	var got []int
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) { got = append(got, p.n) })
+
+	Publish(e, evt, testEvtA{1})
+	if len(got) != 1 || got[0] != 1 {
+		t.Fatalf("FlushImmediate should deliver synchronously inside Publish, got %v", got)
+	}
+
+	Publish(e, evt, testEvtA{2})
+	if len(got) != 2 || got[1] != 2 {
+		t.Fatalf("FlushImmediate should deliver every publish, got %v", got)
+	}
+}
+
+func TestEventFlushImmediateSticky(t *testing.T) {
+	e := newTestECS()
+	evt := RegisterEventType[testEvtA](EventTypeOpts{FlushMode: FlushImmediate, Sticky: true})
+
+	var got []int
+	Subscribe(e, evt, func(ecs *ECS, p testEvtA) { got = append(got, p.n) })
+
+	Publish(e, evt, testEvtA{1})
+	Publish(e, evt, testEvtA{2})
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("sticky + FlushImmediate must still deliver every publish as it happens, got %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package ecs
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// LayerOpts configures a named draw layer created with AddLayer.
+type LayerOpts struct {
+	// Width and Height fix the layer's backing image size. Zero means the
+	// layer is resized to match the screen passed to Draw.
+	Width, Height int
+	// ClearColor clears the layer's image before its drawers run each
+	// frame. The zero value clears to fully transparent.
+	ClearColor color.Color
+	// Blend is the blend mode used when compositing the layer onto the
+	// screen.
+	Blend ebiten.Blend
+}
+
+// layer is a named offscreen image that a group of drawers render onto,
+// composited onto the screen after every Drawer has run. See AddLayer.
+type layer struct {
+	name  string
+	opts  LayerOpts
+	image *ebiten.Image
+}
+
+// AddLayer registers a named draw layer. Route a Drawer to it with
+// SystemOpts.Layer instead of SystemOpts.Image. Each Draw call clears every
+// layer, runs its drawers in priority order, then composites the layers onto
+// the screen in the order AddLayer was called.
+func (ecs *ECS) AddLayer(name string, opts LayerOpts) {
+	ecs.layers = append(ecs.layers, &layer{name: name, opts: opts})
+}
+
+func (ecs *ECS) layerByName(name string) *layer {
+	for _, l := range ecs.layers {
+		if l.name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+func (l *layer) ensure(screen *ebiten.Image) {
+	w, h := l.opts.Width, l.opts.Height
+	if w == 0 || h == 0 {
+		b := screen.Bounds()
+		w, h = b.Dx(), b.Dy()
+	}
+	if l.image == nil || l.image.Bounds().Dx() != w || l.image.Bounds().Dy() != h {
+		l.image = ebiten.NewImage(w, h)
+	}
+	if l.opts.ClearColor != nil {
+		l.image.Fill(l.opts.ClearColor)
+	} else {
+		l.image.Clear()
+	}
+}
+
+func (l *layer) composite(screen *ebiten.Image) {
+	op := &ebiten.DrawImageOptions{Blend: l.opts.Blend}
+	screen.DrawImage(l.image, op)
+}
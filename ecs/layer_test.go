@@ -0,0 +1,72 @@
+package ecs
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type targetRecordingDrawer struct{ target *ebiten.Image }
+
+func (d *targetRecordingDrawer) Draw(ecs *ECS, screen *ebiten.Image) { d.target = screen }
+
+func TestDrawRoutesSameLayerDrawersToTheSameImage(t *testing.T) {
+	e := NewECS(nil)
+	e.AddLayer("fx", LayerOpts{})
+
+	d1 := &targetRecordingDrawer{}
+	d2 := &targetRecordingDrawer{}
+	e.AddSystem(d1, &SystemOpts{Layer: "fx"})
+	e.AddSystem(d2, &SystemOpts{Layer: "fx"})
+
+	screen := ebiten.NewImage(4, 4)
+	e.Draw(screen)
+
+	if d1.target == nil || d2.target == nil {
+		t.Fatalf("expected both drawers to receive a target image")
+	}
+	if d1.target != d2.target {
+		t.Fatalf("expected both drawers routed to layer %q to share the same offscreen image", "fx")
+	}
+	if d1.target == screen {
+		t.Fatalf("expected a layer-routed drawer to draw onto the layer's image, not screen")
+	}
+}
+
+func TestDrawPanicsOnUnregisteredLayer(t *testing.T) {
+	e := NewECS(nil)
+	d := &targetRecordingDrawer{}
+	e.AddSystem(d, &SystemOpts{Layer: "typo'd-layer"})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected Draw to panic when SystemOpts.Layer names an unregistered layer")
+		}
+	}()
+	e.Draw(ebiten.NewImage(4, 4))
+}
+
+type fillDrawer struct{ color color.Color }
+
+func (d *fillDrawer) Draw(ecs *ECS, screen *ebiten.Image) { screen.Fill(d.color) }
+
+func TestDrawCompositesLayersInAddLayerOrder(t *testing.T) {
+	e := NewECS(nil)
+	e.AddLayer("back", LayerOpts{})
+	e.AddLayer("front", LayerOpts{})
+
+	back := color.RGBA{R: 0xff, A: 0xff}
+	front := color.RGBA{B: 0xff, A: 0xff}
+	e.AddSystem(&fillDrawer{color: back}, &SystemOpts{Layer: "back"})
+	e.AddSystem(&fillDrawer{color: front}, &SystemOpts{Layer: "front"})
+
+	screen := ebiten.NewImage(4, 4)
+	e.Draw(screen)
+
+	r, g, b, a := screen.At(0, 0).RGBA()
+	wantR, wantG, wantB, wantA := front.RGBA()
+	if r != wantR || g != wantG || b != wantB || a != wantA {
+		t.Fatalf("expected the layer added last (%q) to composite on top, got rgba(%d,%d,%d,%d)", "front", r, g, b, a)
+	}
+}
@@ -0,0 +1,67 @@
+package ecs
+
+import "github.com/yohamta/donburi"
+
+// Preallocate creates and immediately removes n entities with the given
+// components, so their IDs and component storage exist upfront. Call it
+// during setup for archetypes that will see heavy spawn/despawn churn, to
+// avoid paying for storage growth mid-game. It goes through ecs.World
+// directly rather than CreateEntity/QueueRemove: since every entity it
+// creates is removed before Preallocate returns, there is no net change for
+// SystemOpts.CacheEntities to miss.
+func (ecs *ECS) Preallocate(n int, components ...*donburi.ComponentType) {
+	for i := 0; i < n; i++ {
+		ecs.World.Remove(ecs.World.Create(components...))
+	}
+}
+
+// RemovalPoint controls when entities queued with QueueRemove are actually
+// removed from the world.
+type RemovalPoint int
+
+const (
+	// RemovalEndOfUpdate flushes the removal queue once, after every system
+	// has run for the current Update call. This is the default.
+	RemovalEndOfUpdate RemovalPoint = iota
+	// RemovalBeforeDraw flushes the removal queue at the start of Draw.
+	RemovalBeforeDraw
+	// RemovalManual never flushes the removal queue automatically; call
+	// Flush explicitly.
+	RemovalManual
+)
+
+// SetRemovalPoint changes when QueueRemove's batched removals are applied.
+func (ecs *ECS) SetRemovalPoint(p RemovalPoint) {
+	ecs.removalPoint = p
+}
+
+// QueueRemove batches entry for removal instead of removing it immediately,
+// so systems can keep iterating the world without entities disappearing out
+// from under them mid-frame. The batch is applied at ecs.removalPoint, or by
+// calling Flush directly.
+func (ecs *ECS) QueueRemove(entry *donburi.Entry) {
+	ecs.removeQueue = append(ecs.removeQueue, entry)
+	ecs.markStructuralChange()
+}
+
+// Flush removes every entity queued with QueueRemove. ECS calls it
+// automatically at ecs.removalPoint; call it directly when using
+// RemovalManual or to flush ahead of schedule.
+func (ecs *ECS) Flush() {
+	if len(ecs.removeQueue) == 0 {
+		return
+	}
+	for _, entry := range ecs.removeQueue {
+		ecs.World.Remove(entry.Entity())
+	}
+	ecs.removeQueue = ecs.removeQueue[:0]
+	// QueueRemove already bumped the generation counter when the removal was
+	// queued, so a cache built between then and now correctly saw the
+	// entity as about to disappear and recomputed around it. But a cache
+	// built in that same window still captured the entity itself (it hadn't
+	// actually been removed from the world yet), and nothing bumps the
+	// counter again afterward — so that cache would hold a dangling
+	// *donburi.Entry forever. Bump again here so every cache recomputes at
+	// least once more after the removal actually happens.
+	ecs.markStructuralChange()
+}
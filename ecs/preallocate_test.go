@@ -0,0 +1,38 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/yohamta/donburi"
+)
+
+type churnData struct{ n int }
+
+var churnComponent = donburi.NewComponentType(churnData{})
+
+func BenchmarkChurnCold(b *testing.B) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry := w.Entry(w.Create(churnComponent))
+		e.QueueRemove(entry)
+		e.Flush()
+	}
+}
+
+func BenchmarkChurnPreallocated(b *testing.B) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	e.Preallocate(1024, churnComponent)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry := w.Entry(w.Create(churnComponent))
+		e.QueueRemove(entry)
+		e.Flush()
+	}
+}
@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// EnableProfiling turns per-system instrumentation on or off. It is off by
+// default. Profiling state lives on the ECS instance rather than a package
+// global, so enabling or disabling it here has no effect on any other ECS
+// in the same process.
+func (ecs *ECS) EnableProfiling(enabled bool) {
+	ecs.profilingEnabled = enabled
+}
+
+// SystemStats reports the instrumentation collected for a single system
+// while profiling is enabled. See ECS.Stats.
+type SystemStats struct {
+	// Name identifies the system, as reported by fmt.Sprintf("%T", system).
+	Name string
+	// Entities is the number of entities that matched the system's
+	// SystemOpts.Requires on its last run. It is 0 for a system that
+	// declared no Requires.
+	Entities int
+	// LastDuration is how long the last Update or Draw call took.
+	LastDuration time.Duration
+	// WindowDuration is the time spent in this system over the trailing
+	// 1-second window.
+	WindowDuration time.Duration
+	// WindowCalls is the number of calls counted in WindowDuration.
+	WindowCalls int
+	// TotalDuration is the lifetime total time spent in this system.
+	TotalDuration time.Duration
+	// TotalCalls is the lifetime number of calls.
+	TotalCalls int
+}
+
+// profile accumulates the counters behind a single SystemStats entry. It is
+// held as a field of updater and drawer so recording a sample costs one append, no
+// second traversal of the system lists.
+type profile struct {
+	name     string
+	entities int
+	last     time.Duration
+	total    time.Duration
+	calls    int
+	samples  []profileSample
+}
+
+type profileSample struct {
+	at time.Time
+	d  time.Duration
+}
+
+func (p *profile) record(entities int, d time.Duration) {
+	now := time.Now()
+	p.entities = entities
+	p.last = d
+	p.total += d
+	p.calls++
+	p.samples = append(p.samples, profileSample{at: now, d: d})
+	cutoff := now.Add(-time.Second)
+	i := 0
+	for i < len(p.samples) && p.samples[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		p.samples = p.samples[i:]
+	}
+}
+
+func (p *profile) stats() SystemStats {
+	var windowTotal time.Duration
+	for _, s := range p.samples {
+		windowTotal += s.d
+	}
+	return SystemStats{
+		Name:           p.name,
+		Entities:       p.entities,
+		LastDuration:   p.last,
+		WindowDuration: windowTotal,
+		WindowCalls:    len(p.samples),
+		TotalDuration:  p.total,
+		TotalCalls:     p.calls,
+	}
+}
+
+// Stats returns the current instrumentation for every registered system.
+// It is only populated while profiling is enabled via EnableProfiling.
+func (ecs *ECS) Stats() []SystemStats {
+	stats := make([]SystemStats, 0, len(ecs.updaters)+len(ecs.drawers))
+	for _, u := range ecs.updaters {
+		stats = append(stats, u.profile.stats())
+	}
+	for _, d := range ecs.drawers {
+		stats = append(stats, d.profile.stats())
+	}
+	return stats
+}
+
+// DebugDraw renders a compact overlay of ECS.Stats onto screen, one line per
+// registered system. Enable profiling with EnableProfiling before calling it.
+func (ecs *ECS) DebugDraw(screen *ebiten.Image) {
+	y := 12
+	for _, s := range ecs.Stats() {
+		var avg time.Duration
+		if s.WindowCalls > 0 {
+			avg = s.WindowDuration / time.Duration(s.WindowCalls)
+		}
+		line := fmt.Sprintf("%-24s entities=%-5d last=%-10s avg=%-10s calls/s=%d",
+			s.Name, s.Entities, s.LastDuration, avg, s.WindowCalls)
+		text.Draw(screen, line, basicfont.Face7x13, 4, y, color.White)
+		y += 14
+	}
+}
@@ -0,0 +1,112 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yohamta/donburi"
+)
+
+func TestProfileRecordPrunesSamplesOutsideWindow(t *testing.T) {
+	p := &profile{name: "test"}
+	p.samples = append(p.samples, profileSample{at: time.Now().Add(-2 * time.Second), d: time.Millisecond})
+
+	p.record(3, 5*time.Millisecond)
+
+	if len(p.samples) != 1 {
+		t.Fatalf("expected the sample older than the 1-second window to be pruned, got %d samples", len(p.samples))
+	}
+	stats := p.stats()
+	if stats.WindowCalls != 1 {
+		t.Fatalf("expected WindowCalls to only count the in-window sample, got %d", stats.WindowCalls)
+	}
+	if stats.WindowDuration != 5*time.Millisecond {
+		t.Fatalf("expected WindowDuration to only count the in-window sample, got %s", stats.WindowDuration)
+	}
+}
+
+func TestProfileRecordAccumulatesTotals(t *testing.T) {
+	p := &profile{name: "test"}
+
+	p.record(1, 2*time.Millisecond)
+	p.record(4, 3*time.Millisecond)
+
+	stats := p.stats()
+	if stats.TotalCalls != 2 {
+		t.Fatalf("expected TotalCalls to accumulate across calls, got %d", stats.TotalCalls)
+	}
+	if stats.TotalDuration != 5*time.Millisecond {
+		t.Fatalf("expected TotalDuration to sum every call's duration, got %s", stats.TotalDuration)
+	}
+	if stats.Entities != 4 {
+		t.Fatalf("expected Entities to report the most recent call's count, got %d", stats.Entities)
+	}
+	if stats.LastDuration != 3*time.Millisecond {
+		t.Fatalf("expected LastDuration to report the most recent call's duration, got %s", stats.LastDuration)
+	}
+}
+
+func TestEnableProfilingTogglesInstrumentation(t *testing.T) {
+	e := NewECS(donburi.NewWorld())
+	sys := &countingSystem{}
+	e.AddSystem(sys, nil)
+
+	e.EnableProfiling(false)
+	e.Update()
+	for _, s := range e.Stats() {
+		if s.TotalCalls != 0 {
+			t.Fatalf("expected no instrumentation while profiling is disabled, got %+v", s)
+		}
+	}
+
+	e.EnableProfiling(true)
+	e.Update()
+	e.Update()
+
+	found := false
+	for _, s := range e.Stats() {
+		if s.TotalCalls > 0 {
+			found = true
+			if s.TotalCalls != 2 {
+				t.Fatalf("expected 2 recorded calls after enabling profiling, got %d", s.TotalCalls)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected at least one system to report recorded calls once profiling is enabled")
+	}
+}
+
+// TestEnableProfilingIsPerInstance is the regression test for profiling
+// state living on a package-level variable: two ECS instances must not
+// share a single on/off switch, or enabling profiling on one could silently
+// disable it for another, concurrently-run ECS in the same process.
+func TestEnableProfilingIsPerInstance(t *testing.T) {
+	a := NewECS(donburi.NewWorld())
+	sysA := &countingSystem{}
+	a.AddSystem(sysA, nil)
+
+	b := NewECS(donburi.NewWorld())
+	sysB := &countingSystem{}
+	b.AddSystem(sysB, nil)
+
+	a.EnableProfiling(true)
+	b.EnableProfiling(false)
+
+	a.Update()
+	b.Update()
+
+	var aCalls, bCalls int
+	for _, s := range a.Stats() {
+		aCalls += s.TotalCalls
+	}
+	for _, s := range b.Stats() {
+		bCalls += s.TotalCalls
+	}
+	if aCalls == 0 {
+		t.Fatalf("expected a's systems to be instrumented while a's profiling is enabled")
+	}
+	if bCalls != 0 {
+		t.Fatalf("expected b's systems to stay uninstrumented; enabling profiling on a must not affect b, got %d calls recorded", bCalls)
+	}
+}
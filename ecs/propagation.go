@@ -0,0 +1,66 @@
+package ecs
+
+import "github.com/yohamta/donburi"
+
+// CreateEntity creates a new entity with the given components and marks
+// every system that requires one of them as needing to re-run its query
+// before its next Update or Draw, so a later system in the same frame sees
+// the new entity immediately. donburi has no mutation hooks of its own, so
+// this tracking only sees entities created this way — it is required, not
+// just preferred, for any system registered with SystemOpts.CacheEntities;
+// systems without it re-run their query every call regardless and don't
+// depend on this being called.
+func (ecs *ECS) CreateEntity(components ...*donburi.ComponentType) *donburi.Entry {
+	entity := ecs.World.Create(components...)
+	ecs.markTouched(components...)
+	return ecs.World.Entry(entity)
+}
+
+// AddComponent adds componentType to entry and marks every system that
+// requires it as needing to re-run its query before its next Update or
+// Draw. See CreateEntity: required for SystemOpts.CacheEntities systems to
+// see the change, irrelevant otherwise.
+func (ecs *ECS) AddComponent(entry *donburi.Entry, componentType *donburi.ComponentType) {
+	entry.AddComponent(componentType)
+	ecs.markTouched(componentType)
+}
+
+// RemoveComponent removes componentType from entry and marks every system
+// that requires it as needing to re-run its query before its next Update or
+// Draw. See CreateEntity: required for SystemOpts.CacheEntities systems to
+// see the change, irrelevant otherwise.
+func (ecs *ECS) RemoveComponent(entry *donburi.Entry, componentType *donburi.ComponentType) {
+	entry.RemoveComponent(componentType)
+	ecs.markTouched(componentType)
+}
+
+// markTouched advances ecs's change generation and records it against each
+// of components, so requirements.touchedBy can tell whether a component was
+// touched since a particular cache was last recomputed. A generation
+// counter is used instead of a per-frame reset flag because a cache may go
+// several frames without being recomputed (RemovalManual, or simply no
+// matching system ran), and a reset tied to Update's start would wipe a
+// pending invalidation before a stale cache got a chance to see it — which
+// is exactly what happened to QueueRemove/Flush before this counter
+// existed: Flush runs after Update's systems loop, so the structural change
+// it implies could otherwise be cleared by the next frame's reset before
+// any cache observed it.
+func (ecs *ECS) markTouched(components ...*donburi.ComponentType) {
+	if ecs.touchedGen == nil {
+		ecs.touchedGen = map[*donburi.ComponentType]uint64{}
+	}
+	ecs.changeGen++
+	for _, c := range components {
+		ecs.touchedGen[c] = ecs.changeGen
+	}
+}
+
+// markStructuralChange invalidates every system's cached matched-entity
+// list, rather than only those requiring specific components. QueueRemove
+// uses it because an entity queued for removal may have matched any
+// combination of components, and the removal itself doesn't happen until a
+// later Flush call.
+func (ecs *ECS) markStructuralChange() {
+	ecs.changeGen++
+	ecs.structuralGen = ecs.changeGen
+}
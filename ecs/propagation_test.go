@@ -0,0 +1,186 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/yohamta/donburi"
+)
+
+func TestRequirementsTouchedBy(t *testing.T) {
+	e := newTestECS()
+	a := donburi.NewComponentType(reqTestDataA{})
+	b := donburi.NewComponentType(reqTestDataB{})
+	r := newRequirements([]*donburi.ComponentType{a}, nil, true)
+
+	seenGen := e.changeGen
+	if r.touchedBy(e, seenGen) {
+		t.Fatalf("expected no changes since seenGen to report no changes")
+	}
+
+	e.markTouched(b)
+	if r.touchedBy(e, seenGen) {
+		t.Fatalf("expected a change to an undeclared component to not invalidate the cache")
+	}
+
+	e.markTouched(a)
+	if !r.touchedBy(e, seenGen) {
+		t.Fatalf("expected a change to a required component to invalidate the cache")
+	}
+
+	seenGen = e.changeGen
+	if r.touchedBy(e, seenGen) {
+		t.Fatalf("expected recomputing against the current generation to clear the invalidation")
+	}
+
+	e.markStructuralChange()
+	if !r.touchedBy(e, seenGen) {
+		t.Fatalf("expected a structural change to invalidate every cache")
+	}
+}
+
+// TestQueueRemoveInvalidatesCacheAcrossFrames is the regression test for a
+// despawn system that caches its own matched entities: QueueRemove marks the
+// structural change immediately, but the actual removal doesn't happen
+// until a later Flush call, potentially after the next frame's Update has
+// already started. The cache must still see the removal once Flush runs,
+// even though that can be a whole Update call after QueueRemove. It checks
+// ecs.MatchedEntries(sys) directly rather than something sys records from
+// inside its own Update, since a system is skipped entirely once its cache
+// reports zero matches — exactly the case this test needs to observe.
+func TestQueueRemoveInvalidatesCacheAcrossFrames(t *testing.T) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	var removeOnNextRun bool
+	sys := &despawningSystem{
+		onUpdate: func(entries []*donburi.Entry) {
+			if removeOnNextRun {
+				e.QueueRemove(entries[0])
+				removeOnNextRun = false
+			}
+		},
+	}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}, CacheEntities: true})
+
+	e.CreateEntity(a)
+	e.Update()
+	if len(e.MatchedEntries(sys)) != 1 {
+		t.Fatalf("expected the system to see the created entity, got %d matches", len(e.MatchedEntries(sys)))
+	}
+
+	removeOnNextRun = true
+	e.Update()
+
+	e.Update()
+	if len(e.MatchedEntries(sys)) != 0 {
+		t.Fatalf("expected the cache to drop the entity removed via QueueRemove, got %d matches", len(e.MatchedEntries(sys)))
+	}
+}
+
+// TestQueueRemoveInvalidatesSecondSystemsCacheAfterFlush is the regression
+// test for the cross-cache timing window QueueRemove's generation bump alone
+// doesn't cover: a higher-priority system queues a removal, a second,
+// CacheEntities system recomputes and caches the entity *before* Flush
+// actually removes it (touchedBy sees QueueRemove's bump and is satisfied),
+// and nothing bumps the generation again afterward unless Flush itself
+// does. Without Flush also calling markStructuralChange, the second
+// system's cache would hold the dangling entry forever.
+func TestQueueRemoveInvalidatesSecondSystemsCacheAfterFlush(t *testing.T) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	var removeOnNextRun bool
+	remover := &despawningSystem{
+		onUpdate: func(entries []*donburi.Entry) {
+			if removeOnNextRun && len(entries) > 0 {
+				e.QueueRemove(entries[0])
+				removeOnNextRun = false
+			}
+		},
+	}
+	observer := &despawningSystem{onUpdate: func(entries []*donburi.Entry) {}}
+
+	// Higher priority so remover runs, and queues the removal, before
+	// observer recomputes its own cache in the same Update call.
+	e.AddSystem(remover, &SystemOpts{Requires: []*donburi.ComponentType{a}, Priority: 1})
+	e.AddSystem(observer, &SystemOpts{Requires: []*donburi.ComponentType{a}, CacheEntities: true})
+
+	e.CreateEntity(a)
+	e.Update()
+	if len(e.MatchedEntries(observer)) != 1 {
+		t.Fatalf("expected observer to see the created entity, got %d matches", len(e.MatchedEntries(observer)))
+	}
+
+	removeOnNextRun = true
+	e.Update()
+	if len(e.MatchedEntries(observer)) != 1 {
+		t.Fatalf("expected observer's cache, rebuilt before Flush removed the entity, to still report it this frame, got %d matches", len(e.MatchedEntries(observer)))
+	}
+
+	e.Update()
+	if len(e.MatchedEntries(observer)) != 0 {
+		t.Fatalf("expected observer's cache to be invalidated once Flush actually removed the entity, got %d matches", len(e.MatchedEntries(observer)))
+	}
+}
+
+type despawningSystem struct {
+	onUpdate func(entries []*donburi.Entry)
+}
+
+func (s *despawningSystem) Update(ecs *ECS) {
+	s.onUpdate(ecs.MatchedEntries(s))
+}
+
+// TestUpdateDefaultDoesNotTrustStaleCacheOnRawMutations is the regression
+// test for the footgun this file used to ship: a system that never opts
+// into SystemOpts.CacheEntities must keep seeing entities created through
+// the raw donburi API (not just ecs.CreateEntity), even after it was
+// previously skipped for having zero matches.
+func TestUpdateDefaultDoesNotTrustStaleCacheOnRawMutations(t *testing.T) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	sys := &countingSystem{}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}})
+
+	e.Update()
+	if sys.calls != 0 {
+		t.Fatalf("expected system to be skipped with zero matching entities, got %d calls", sys.calls)
+	}
+
+	// Bypasses ecs.CreateEntity entirely, the way this repo's own
+	// ecs.Preallocate and most existing systems create entities.
+	w.Entry(w.Create(a))
+
+	e.Update()
+	if sys.calls != 1 {
+		t.Fatalf("expected the system to see the entity created via the raw donburi API, got %d calls", sys.calls)
+	}
+}
+
+// TestUpdateCacheEntitiesRequiresTrackedMutations documents the tradeoff of
+// opting into SystemOpts.CacheEntities: it only sees entities created
+// through the tracked ecs.CreateEntity/AddComponent/RemoveComponent/
+// QueueRemove wrappers.
+func TestUpdateCacheEntitiesRequiresTrackedMutations(t *testing.T) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	sys := &countingSystem{}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}, CacheEntities: true})
+
+	e.Update()
+	if sys.calls != 0 {
+		t.Fatalf("expected system to be skipped with zero matching entities, got %d calls", sys.calls)
+	}
+
+	e.CreateEntity(a)
+	e.Update()
+	if sys.calls != 1 {
+		t.Fatalf("expected the system to see the entity created via ecs.CreateEntity, got %d calls", sys.calls)
+	}
+}
@@ -0,0 +1,124 @@
+package ecs
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/yohamta/donburi"
+	"github.com/yohamta/donburi/filter"
+	"github.com/yohamta/donburi/query"
+)
+
+// debugMode enables the access-enforcement panics in AccessComponent. It is
+// controlled by the DONBURI_DEBUG environment variable, read once at package
+// initialisation, so the checks can be switched on during development and
+// left off in shipping builds without touching call sites.
+var debugMode = os.Getenv("DONBURI_DEBUG") == "1"
+
+// requirements holds a system's declared component access and the cached
+// query built from it.
+type requirements struct {
+	requires      []*donburi.ComponentType
+	uses          []*donburi.ComponentType
+	query         *query.Query
+	cacheEntities bool
+}
+
+func newRequirements(requires, uses []*donburi.ComponentType, cacheEntities bool) requirements {
+	r := requirements{requires: requires, uses: uses, cacheEntities: cacheEntities}
+	if len(requires) > 0 {
+		r.query = query.NewQuery(filter.Contains(requires...))
+	}
+	return r
+}
+
+// matchedEntries returns the entities currently matching r's required
+// components, or nil if no components were required.
+func (r *requirements) matchedEntries(w donburi.World) []*donburi.Entry {
+	if r.query == nil {
+		return nil
+	}
+	var entries []*donburi.Entry
+	r.query.Each(w, func(entry *donburi.Entry) {
+		entries = append(entries, entry)
+	})
+	return entries
+}
+
+// touchedBy reports whether any component r requires was created, removed,
+// or added/removed from an entity, or an entity was queued for removal or
+// removed by Flush, more recently than seenGen — the change generation a system's
+// cache was built against. It is how the ECS decides whether a system's
+// cached matchedEntries is stale.
+func (r *requirements) touchedBy(ecs *ECS, seenGen uint64) bool {
+	if ecs.structuralGen > seenGen {
+		return true
+	}
+	for _, d := range r.requires {
+		if ecs.touchedGen[d] > seenGen {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *requirements) declares(c *donburi.ComponentType) bool {
+	for _, d := range r.requires {
+		if d == c {
+			return true
+		}
+	}
+	for _, d := range r.uses {
+		if d == c {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessComponent returns entry's data for componentType on behalf of
+// system. When DONBURI_DEBUG=1, it panics if system did not declare
+// componentType in SystemOpts.Requires or SystemOpts.Uses when it was
+// registered with AddSystem, catching systems that read components they
+// never declared an intent to touch.
+func (ecs *ECS) AccessComponent(system interface{}, entry *donburi.Entry, componentType *donburi.ComponentType) unsafe.Pointer {
+	if debugMode {
+		r, ok := ecs.requirementsOf(system)
+		if !ok || !r.declares(componentType) {
+			panic(fmt.Sprintf("donburi/ecs: %T accessed a component it did not declare; add it to SystemOpts.Requires or SystemOpts.Uses", system))
+		}
+	}
+	return entry.Component(componentType)
+}
+
+// MatchedEntries returns the entities matching system's SystemOpts.Requires,
+// as cached for the Update or Draw call currently in progress. It returns
+// nil for systems that declared no required components.
+func (ecs *ECS) MatchedEntries(system interface{}) []*donburi.Entry {
+	for _, u := range ecs.updaters {
+		if u.Updater == system {
+			return u.matched
+		}
+	}
+	for _, d := range ecs.drawers {
+		if d.Drawer == system {
+			return d.matched
+		}
+	}
+	return nil
+}
+
+func (ecs *ECS) requirementsOf(system interface{}) (*requirements, bool) {
+	for _, u := range ecs.updaters {
+		if u.Updater == system {
+			return &u.reqs, true
+		}
+	}
+	for _, d := range ecs.drawers {
+		if d.Drawer == system {
+			return &d.reqs, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/yohamta/donburi"
+)
+
+type reqTestDataA struct{}
+type reqTestDataB struct{}
+
+func TestRequirementsDeclares(t *testing.T) {
+	a := donburi.NewComponentType(reqTestDataA{})
+	b := donburi.NewComponentType(reqTestDataB{})
+
+	r := newRequirements([]*donburi.ComponentType{a}, nil, false)
+
+	if !r.declares(a) {
+		t.Fatalf("expected a required component to be declared")
+	}
+	if r.declares(b) {
+		t.Fatalf("expected an undeclared component to not be declared")
+	}
+}
+
+func TestRequirementsUses(t *testing.T) {
+	a := donburi.NewComponentType(reqTestDataA{})
+	b := donburi.NewComponentType(reqTestDataB{})
+
+	r := newRequirements(nil, []*donburi.ComponentType{b}, false)
+
+	if r.declares(a) {
+		t.Fatalf("expected a component outside Requires/Uses to not be declared")
+	}
+	if !r.declares(b) {
+		t.Fatalf("expected an optionally-used component to be declared")
+	}
+	if r.query != nil {
+		t.Fatalf("expected no cached query when Requires is empty")
+	}
+}
+
+type accessTestSystem struct{}
+
+func (*accessTestSystem) Update(ecs *ECS) {}
+
+func TestAccessComponentPanicsOnUndeclaredComponentInDebugMode(t *testing.T) {
+	old := debugMode
+	debugMode = true
+	defer func() { debugMode = old }()
+
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+	b := donburi.NewComponentType(reqTestDataB{})
+
+	sys := &accessTestSystem{}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}})
+	entry := w.Entry(w.Create(a, b))
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected AccessComponent to panic for a component outside Requires/Uses")
+		}
+	}()
+	e.AccessComponent(sys, entry, b)
+}
+
+func TestAccessComponentAllowsDeclaredComponentInDebugMode(t *testing.T) {
+	old := debugMode
+	debugMode = true
+	defer func() { debugMode = old }()
+
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	sys := &accessTestSystem{}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}})
+	entry := w.Entry(w.Create(a))
+
+	e.AccessComponent(sys, entry, a)
+}
+
+type countingSystem struct{ calls int }
+
+func (s *countingSystem) Update(ecs *ECS) { s.calls++ }
+
+func TestUpdateSkipsSystemWithNoMatchingEntities(t *testing.T) {
+	w := donburi.NewWorld()
+	e := NewECS(w)
+	a := donburi.NewComponentType(reqTestDataA{})
+
+	sys := &countingSystem{}
+	e.AddSystem(sys, &SystemOpts{Requires: []*donburi.ComponentType{a}})
+
+	e.Update()
+	if sys.calls != 0 {
+		t.Fatalf("expected system to be skipped with zero matching entities, got %d calls", sys.calls)
+	}
+
+	w.Entry(w.Create(a))
+	e.Update()
+	if sys.calls != 1 {
+		t.Fatalf("expected system to run once a matching entity exists, got %d calls", sys.calls)
+	}
+}